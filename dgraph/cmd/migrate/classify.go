@@ -0,0 +1,85 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import "sort"
+
+// classifyTables inspects each table and flags the ones that look like a
+// many-to-many join table, so that downstream schema/RDF generation can
+// emit them as a bidirectional edge between the two tables they relate
+// instead of as a node type of their own. Join-table detection only looks at
+// a table's own foreign keys, primary key and columns, not at
+// constraintSources, so it doesn't depend on populateReferencedByColumns.
+func classifyTables(tables map[string]*TableInfo) {
+	for _, tableInfo := range tables {
+		tableInfo.IsJoinTable, tableInfo.JoinEdges = classifyTable(tableInfo)
+	}
+}
+
+// classifyTable reports whether tableInfo looks like a many-to-many
+// association table: exactly two foreign key constraints whose columns
+// make up the entire primary key, and no other non-key columns apart from
+// the metadata columns created_at and updated_at.
+func classifyTable(tableInfo *TableInfo) (bool, [2]*ForeignKeyConstraint) {
+	var edges [2]*ForeignKeyConstraint
+	if len(tableInfo.foreignKeyConstraints) != 2 {
+		return false, edges
+	}
+
+	fkColumns := make(map[string]bool)
+	i := 0
+	for _, constraintName := range sortedConstraintNames(tableInfo) {
+		edges[i] = tableInfo.foreignKeyConstraints[constraintName]
+		i++
+		for _, part := range tableInfo.foreignKeyConstraints[constraintName].parts {
+			fkColumns[part.columnName] = true
+		}
+	}
+
+	if len(fkColumns) != len(tableInfo.primaryKeyColumns) {
+		return false, edges
+	}
+	for _, pkColumn := range tableInfo.primaryKeyColumns {
+		if !fkColumns[pkColumn] {
+			return false, edges
+		}
+	}
+
+	for columnName, columnInfo := range tableInfo.columns {
+		if fkColumns[columnName] {
+			continue
+		}
+		if columnInfo.keyType == PRIMARY {
+			continue
+		}
+		// allow trivial metadata columns to not disqualify a join table
+		if columnName == "created_at" || columnName == "updated_at" {
+			continue
+		}
+		return false, edges
+	}
+	return true, edges
+}
+
+func sortedConstraintNames(tableInfo *TableInfo) []string {
+	names := make([]string, 0, len(tableInfo.foreignKeyConstraints))
+	for name := range tableInfo.foreignKeyConstraints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
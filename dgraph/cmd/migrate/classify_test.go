@@ -0,0 +1,81 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import "testing"
+
+func joinTableFixture() *TableInfo {
+	return &TableInfo{
+		tableName:         "registration",
+		primaryKeyColumns: []string{"student_id", "course_id"},
+		columns: map[string]*ColumnInfo{
+			"student_id": {keyType: PRIMARY},
+			"course_id":  {keyType: PRIMARY},
+			"created_at": {},
+		},
+		foreignKeyConstraints: map[string]*ForeignKeyConstraint{
+			"fk_registration_student": {parts: []*ConstraintPart{
+				{tableName: "registration", columnName: "student_id", remoteTableName: "student", remoteColumnName: "id"},
+			}},
+			"fk_registration_course": {parts: []*ConstraintPart{
+				{tableName: "registration", columnName: "course_id", remoteTableName: "course", remoteColumnName: "id"},
+			}},
+		},
+	}
+}
+
+func TestClassifyTablePositive(t *testing.T) {
+	isJoin, edges := classifyTable(joinTableFixture())
+	if !isJoin {
+		t.Fatal("expected registration to be classified as a join table")
+	}
+	if edges[0] == nil || edges[1] == nil {
+		t.Fatalf("expected both join edges to be populated, got %v", edges)
+	}
+}
+
+func TestClassifyTableRejectsExtraDataColumn(t *testing.T) {
+	tableInfo := joinTableFixture()
+	tableInfo.columns["grade"] = &ColumnInfo{}
+
+	if isJoin, _ := classifyTable(tableInfo); isJoin {
+		t.Fatal("a non-key, non-metadata column should disqualify the table from being a join table")
+	}
+}
+
+func TestClassifyTableRejectsWrongForeignKeyCount(t *testing.T) {
+	tableInfo := joinTableFixture()
+	tableInfo.foreignKeyConstraints["fk_registration_faculty"] = &ForeignKeyConstraint{
+		parts: []*ConstraintPart{
+			{tableName: "registration", columnName: "faculty_id", remoteTableName: "faculty", remoteColumnName: "id"},
+		},
+	}
+
+	if isJoin, _ := classifyTable(tableInfo); isJoin {
+		t.Fatal("a table with more than two foreign key constraints should not be classified as a join table")
+	}
+}
+
+func TestClassifyTableRejectsPartialPrimaryKeyCoverage(t *testing.T) {
+	tableInfo := joinTableFixture()
+	tableInfo.primaryKeyColumns = []string{"student_id", "course_id", "id"}
+	tableInfo.columns["id"] = &ColumnInfo{keyType: PRIMARY}
+
+	if isJoin, _ := classifyTable(tableInfo); isJoin {
+		t.Fatal("a primary key not fully covered by the two foreign keys should not be classified as a join table")
+	}
+}
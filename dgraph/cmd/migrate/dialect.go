@@ -0,0 +1,73 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ForeignKeyRow represents a single row returned while listing the foreign
+// keys declared on a table. Multiple rows sharing the same constraintName
+// are parts of the same (possibly composite) ForeignKeyConstraint.
+type ForeignKeyRow struct {
+	columnName           string
+	constraintName       string
+	referencedTableName  string
+	referencedColumnName string
+}
+
+// Dialect abstracts the SQL-flavor-specific pieces of schema introspection so
+// that the rest of the migrate package can work against any supported
+// database without knowing which one it is.
+type Dialect interface {
+	// ListTables returns the names of the user tables visible to pool.
+	ListTables(pool *sql.DB) ([]string, error)
+
+	// DescribeColumns returns the columns declared on table, in declaration
+	// order.
+	DescribeColumns(pool *sql.DB, table string) ([]*ColumnOutput, error)
+
+	// ListForeignKeys returns the foreign key constraints declared on table.
+	ListForeignKeys(pool *sql.DB, table string) ([]*ForeignKeyRow, error)
+
+	// MapType maps a dialect-specific column data type, e.g. "varchar(50)"
+	// or "numeric(10,2)", to the DataType used internally by migrate.
+	MapType(dataType string) DataType
+
+	// SampleClause returns a SQL boolean expression that, used in a WHERE
+	// clause, keeps roughly the given fraction (0, 1] of rows.
+	SampleClause(fraction float64) string
+
+	// Placeholder returns the driver-specific parameter placeholder for the
+	// n-th (1-based) bound argument of a query, e.g. "?" for MySQL or "$2"
+	// for the second argument under Postgres.
+	Placeholder(n int) string
+}
+
+// getDialect returns the Dialect implementation named by driver, which is
+// expected to be one of "mysql" or "postgres".
+func getDialect(driver string) (Dialect, error) {
+	switch driver {
+	case "mysql":
+		return &mysqlDialect{}, nil
+	case "postgres":
+		return &postgresDialect{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported driver %q, must be one of mysql, postgres", driver)
+	}
+}
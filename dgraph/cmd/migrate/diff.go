@@ -0,0 +1,194 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+// TableDiff describes how a single table changed between two snapshots.
+type TableDiff struct {
+	AddedColumns   []string
+	RemovedColumns []string
+	// RenamedColumns maps the old column name to the new one, detected via
+	// the position+datatype+keytype heuristic in detectRenames.
+	RenamedColumns     map[string]string
+	AddedForeignKeys   []string
+	RemovedForeignKeys []string
+}
+
+// SchemaDiff describes how a schemaSnapshot changed relative to a prior one.
+type SchemaDiff struct {
+	AddedTables   []string
+	RemovedTables []string
+	ChangedTables map[string]*TableDiff
+}
+
+// diffSnapshots compares old against current and reports what changed.
+// A nil old (no prior snapshot) is treated as an empty schema, so
+// everything in current shows up as added.
+func diffSnapshots(old, current *schemaSnapshot) *SchemaDiff {
+	oldTables := map[string]*tableSnapshot{}
+	if old != nil {
+		oldTables = old.Tables
+	}
+
+	diff := &SchemaDiff{ChangedTables: make(map[string]*TableDiff)}
+	for tableName := range current.Tables {
+		if _, ok := oldTables[tableName]; !ok {
+			diff.AddedTables = append(diff.AddedTables, tableName)
+		}
+	}
+	for tableName := range oldTables {
+		if _, ok := current.Tables[tableName]; !ok {
+			diff.RemovedTables = append(diff.RemovedTables, tableName)
+		}
+	}
+
+	for tableName, currentTable := range current.Tables {
+		oldTable, ok := oldTables[tableName]
+		if !ok {
+			continue
+		}
+		if tableDiff := diffTable(oldTable, currentTable); tableDiff != nil {
+			diff.ChangedTables[tableName] = tableDiff
+		}
+	}
+	return diff
+}
+
+// diffTable compares two versions of the same table and returns nil if no
+// column or foreign key constraint was added, removed or renamed. Columns
+// are matched by name, so a column whose datatype or key type changed under
+// the same name is not reported.
+func diffTable(old, current *tableSnapshot) *TableDiff {
+	oldByName := columnsByName(old)
+	currentByName := columnsByName(current)
+
+	var removed, added []string
+	for name := range oldByName {
+		if _, ok := currentByName[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	for name := range currentByName {
+		if _, ok := oldByName[name]; !ok {
+			added = append(added, name)
+		}
+	}
+
+	renames := detectRenames(old, current, removed, added)
+	removed = subtractKeys(removed, renames)
+	added = subtractValues(added, renames)
+
+	oldFKs := foreignKeysByConstraint(old)
+	currentFKs := foreignKeysByConstraint(current)
+	var removedFKs, addedFKs []string
+	for name := range oldFKs {
+		if _, ok := currentFKs[name]; !ok {
+			removedFKs = append(removedFKs, name)
+		}
+	}
+	for name := range currentFKs {
+		if _, ok := oldFKs[name]; !ok {
+			addedFKs = append(addedFKs, name)
+		}
+	}
+
+	if len(removed) == 0 && len(added) == 0 && len(renames) == 0 &&
+		len(removedFKs) == 0 && len(addedFKs) == 0 {
+		return nil
+	}
+	return &TableDiff{
+		AddedColumns:       added,
+		RemovedColumns:     removed,
+		RenamedColumns:     renames,
+		AddedForeignKeys:   addedFKs,
+		RemovedForeignKeys: removedFKs,
+	}
+}
+
+// detectRenames pairs up a removed column with an added one when they sit
+// at the same ordinal position and share a datatype and keytype: same
+// position + same datatype + same key type across snapshots is taken to
+// mean the column was renamed rather than dropped and recreated.
+func detectRenames(old, current *tableSnapshot, removed, added []string) map[string]string {
+	renames := make(map[string]string)
+	removedSet := toSet(removed)
+	addedSet := toSet(added)
+
+	for i, oldColumn := range old.Columns {
+		if !removedSet[oldColumn.Name] {
+			continue
+		}
+		if i >= len(current.Columns) {
+			continue
+		}
+		candidate := current.Columns[i]
+		if !addedSet[candidate.Name] {
+			continue
+		}
+		if candidate.DataType == oldColumn.DataType && candidate.KeyType == oldColumn.KeyType {
+			renames[oldColumn.Name] = candidate.Name
+		}
+	}
+	return renames
+}
+
+func columnsByName(table *tableSnapshot) map[string]columnSnapshot {
+	m := make(map[string]columnSnapshot, len(table.Columns))
+	for _, column := range table.Columns {
+		m[column.Name] = column
+	}
+	return m
+}
+
+func foreignKeysByConstraint(table *tableSnapshot) map[string]foreignKeySnapshot {
+	m := make(map[string]foreignKeySnapshot, len(table.ForeignKeys))
+	for _, fk := range table.ForeignKeys {
+		m[fk.ConstraintName] = fk
+	}
+	return m
+}
+
+func toSet(values []string) map[string]bool {
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+	return m
+}
+
+func subtractKeys(values []string, renames map[string]string) []string {
+	var result []string
+	for _, v := range values {
+		if _, ok := renames[v]; !ok {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func subtractValues(values []string, renames map[string]string) []string {
+	renamed := make(map[string]bool, len(renames))
+	for _, newName := range renames {
+		renamed[newName] = true
+	}
+	var result []string
+	for _, v := range values {
+		if !renamed[v] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+)
+
+// generateDiffSchema writes an alter patch for diff: new predicate
+// declarations for every added table and every added column/foreign key on
+// a changed table, plus a comment trailer listing the renamed and removed
+// column predicates and the removed tables so the operator can issue the
+// corresponding Alter drop-predicate calls by hand. Removed foreign key
+// constraints are not listed in the trailer.
+func generateDiffSchema(diff *SchemaDiff, tables map[string]*TableInfo, w io.Writer) error {
+	for _, tableName := range diff.AddedTables {
+		tableInfo := tables[tableName]
+		if tableInfo.IsJoinTable {
+			if err := generateJoinTableSchema(tableInfo, w); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := generateTableSchema(tableInfo, w); err != nil {
+			return err
+		}
+	}
+
+	var dropped, renamed []string
+	for tableName, tableDiff := range diff.ChangedTables {
+		tableInfo := tables[tableName]
+		for _, columnName := range tableDiff.AddedColumns {
+			columnInfo := tableInfo.columns[columnName]
+			predicate := fmt.Sprintf("%s.%s", tableName, columnName)
+			if _, err := fmt.Fprintf(w, "%s: %s .\n", predicate,
+				dgraphScalarType(columnInfo.dataType)); err != nil {
+				return err
+			}
+		}
+		for _, constraintName := range tableDiff.AddedForeignKeys {
+			constraint := tableInfo.foreignKeyConstraints[constraintName]
+			if _, err := fmt.Fprintf(w, "%s: uid @reverse .\n",
+				predicateName(tableInfo, constraint)); err != nil {
+				return err
+			}
+		}
+		for oldName, newName := range tableDiff.RenamedColumns {
+			// generateDiffRDF re-emits the table's rows under the live
+			// (post-rename) column name regardless, so the new predicate
+			// needs its own declaration here or the delta would ship data
+			// for a predicate the patch never declared.
+			columnInfo := tableInfo.columns[newName]
+			predicate := fmt.Sprintf("%s.%s", tableName, newName)
+			if _, err := fmt.Fprintf(w, "%s: %s .\n", predicate,
+				dgraphScalarType(columnInfo.dataType)); err != nil {
+				return err
+			}
+			renamed = append(renamed, fmt.Sprintf("%s.%s -> %s.%s", tableName, oldName,
+				tableName, newName))
+		}
+		for _, columnName := range tableDiff.RemovedColumns {
+			dropped = append(dropped, fmt.Sprintf("%s.%s", tableName, columnName))
+		}
+	}
+	for _, tableName := range diff.RemovedTables {
+		dropped = append(dropped, tableName+".*")
+	}
+
+	for _, rename := range renamed {
+		if _, err := fmt.Fprintf(w, "# renamed predicate: %s\n", rename); err != nil {
+			return err
+		}
+	}
+	for _, predicate := range dropped {
+		if _, err := fmt.Fprintf(w, "# dropped predicate: %s\n", predicate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generateDiffRDF re-emits RDF only for the tables diff says are new or
+// changed, so keeping a Dgraph instance in sync with a growing relational
+// source doesn't require a full re-import.
+func generateDiffRDF(diff *SchemaDiff, tables map[string]*TableInfo, pool *sql.DB, w io.Writer) error {
+	changed := make(map[string]bool)
+	for _, tableName := range diff.AddedTables {
+		changed[tableName] = true
+	}
+	for tableName := range diff.ChangedTables {
+		changed[tableName] = true
+	}
+
+	for _, tableName := range sortedTableNames(tables) {
+		if !changed[tableName] {
+			continue
+		}
+		tableInfo := tables[tableName]
+		if tableInfo.IsJoinTable {
+			if err := generateJoinTableRDF(tables, tableInfo, pool, w); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := generateTableRDF(tables, tableInfo, pool, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDetectRenamesMatchesOnPositionDataTypeAndKeyType(t *testing.T) {
+	old := &tableSnapshot{Columns: []columnSnapshot{
+		{Name: "id", DataType: IntType, KeyType: PRIMARY},
+		{Name: "full_name", DataType: StringType, KeyType: NONE},
+	}}
+	current := &tableSnapshot{Columns: []columnSnapshot{
+		{Name: "id", DataType: IntType, KeyType: PRIMARY},
+		{Name: "display_name", DataType: StringType, KeyType: NONE},
+	}}
+
+	renames := detectRenames(old, current, []string{"full_name"}, []string{"display_name"})
+	if got := renames["full_name"]; got != "display_name" {
+		t.Fatalf("detectRenames = %v, want full_name -> display_name", renames)
+	}
+}
+
+func TestGenerateDiffSchemaDeclaresRenamedPredicate(t *testing.T) {
+	tableInfo := &TableInfo{
+		tableName: "student",
+		columns: map[string]*ColumnInfo{
+			"display_name": {dataType: StringType},
+		},
+		foreignKeyConstraints: map[string]*ForeignKeyConstraint{},
+	}
+	diff := &SchemaDiff{
+		ChangedTables: map[string]*TableDiff{
+			"student": {RenamedColumns: map[string]string{"full_name": "display_name"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := generateDiffSchema(diff, map[string]*TableInfo{"student": tableInfo}, &buf); err != nil {
+		t.Fatalf("generateDiffSchema: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "student.display_name:") {
+		t.Fatalf("renamed column's new predicate was never declared; got:\n%s", out)
+	}
+	if !strings.Contains(out, "student.full_name -> student.display_name") {
+		t.Fatalf("expected a rename note for student.full_name -> student.display_name; got:\n%s", out)
+	}
+}
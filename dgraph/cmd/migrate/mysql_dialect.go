@@ -0,0 +1,147 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// mysqlTypePrefixToGoType maps the prefix of a MySQL column type, as
+// reported by INFORMATION_SCHEMA.COLUMNS.DATA_TYPE, to the DataType used
+// internally by migrate.
+var mysqlTypePrefixToGoType = map[string]DataType{
+	"int":       IntType,
+	"bigint":    Int64Type,
+	"smallint":  IntType,
+	"tinyint":   IntType,
+	"float":     FloatType,
+	"double":    FloatType,
+	"decimal":   FloatType,
+	"bool":      BoolType,
+	"boolean":   BoolType,
+	"varchar":   StringType,
+	"char":      StringType,
+	"text":      StringType,
+	"datetime":  DateTimeType,
+	"timestamp": DateTimeType,
+	"date":      DateTimeType,
+}
+
+// mysqlDialect implements Dialect against MySQL's INFORMATION_SCHEMA.
+type mysqlDialect struct{}
+
+func (m *mysqlDialect) ListTables(pool *sql.DB) ([]string, error) {
+	rows, err := pool.Query(`select TABLE_NAME from INFORMATION_SCHEMA.TABLES
+where TABLE_SCHEMA = database()`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, fmt.Errorf("unable to scan table name: %v", err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func (m *mysqlDialect) DescribeColumns(pool *sql.DB, table string) ([]*ColumnOutput, error) {
+	query := fmt.Sprintf(`select COLUMN_NAME,DATA_TYPE,
+COLUMN_KEY from INFORMATION_SCHEMA.COLUMNS where TABLE_NAME = "%s"
+order by ORDINAL_POSITION`, table)
+	rows, err := pool.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []*ColumnOutput
+	for rows.Next() {
+		/*
+			each row represents info about a column, for example
+			+----------+-------------+------+-----+---------+-------+
+			| Field    | Type        | Null | Key | Default | Extra |
+			+----------+-------------+------+-----+---------+-------+
+			| ssn      | varchar(50) | NO   | PRI | NULL    |       |
+		*/
+		columnOutput := &ColumnOutput{}
+		err := rows.Scan(&columnOutput.fieldName, &columnOutput.dataType, &columnOutput.keyType)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan table description result for table %s: %v",
+				table, err)
+		}
+		columns = append(columns, columnOutput)
+	}
+	return columns, nil
+}
+
+func (m *mysqlDialect) ListForeignKeys(pool *sql.DB, table string) ([]*ForeignKeyRow, error) {
+	query := fmt.Sprintf(`select COLUMN_NAME, CONSTRAINT_NAME, REFERENCED_TABLE_NAME,
+		REFERENCED_COLUMN_NAME from INFORMATION_SCHEMA.KEY_COLUMN_USAGE where TABLE_NAME = "%s"
+        AND REFERENCED_TABLE_NAME IS NOT NULL`,
+		table)
+	rows, err := pool.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fkRows []*ForeignKeyRow
+	for rows.Next() {
+		/* example output from MySQL when querying the registration table
+		+-------------+-------------------------+-----------------------+------------------------+
+		| COLUMN_NAME | CONSTRAINT_NAME         | REFERENCED_TABLE_NAME | REFERENCED_COLUMN_NAME |
+		+-------------+-------------------------+-----------------------+------------------------+
+		| student_id  | fk_registration_student | student               | id                     |
+		| course_id   | fk_registration_course  | course                | id                     |
+		| faculty_id  | fk_registration_faculty | faculty               | id                     |
+		+-------------+-------------------------+-----------------------+------------------------+
+
+		*/
+		fkRow := &ForeignKeyRow{}
+		err := rows.Scan(&fkRow.columnName, &fkRow.constraintName, &fkRow.referencedTableName,
+			&fkRow.referencedColumnName)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan usage info for table %s: %v", table, err)
+		}
+		fkRows = append(fkRows, fkRow)
+	}
+	return fkRows, nil
+}
+
+func (m *mysqlDialect) MapType(dataType string) DataType {
+	for prefix, goType := range mysqlTypePrefixToGoType {
+		if strings.HasPrefix(dataType, prefix) {
+			return goType
+		}
+	}
+	return UnknownType
+}
+
+func (m *mysqlDialect) SampleClause(fraction float64) string {
+	return fmt.Sprintf("RAND() <= %f", fraction)
+}
+
+func (m *mysqlDialect) Placeholder(n int) string {
+	return "?"
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// postgresTypePrefixToGoType maps the prefix of a Postgres column type, as
+// reported by information_schema.columns.data_type, to the DataType
+// used internally by migrate.
+var postgresTypePrefixToGoType = map[string]DataType{
+	"serial":            IntType,
+	"bigserial":         Int64Type,
+	"smallint":          IntType,
+	"integer":           IntType,
+	"bigint":            Int64Type,
+	"boolean":           BoolType,
+	"text":              StringType,
+	"character varying": StringType,
+	"varchar":           StringType,
+	"char":              StringType,
+	"numeric":           FloatType,
+	"real":              FloatType,
+	"double precision":  FloatType,
+	"timestamp":         DateTimeType,
+	"date":              DateTimeType,
+	"uuid":              StringType,
+}
+
+// postgresDialect implements Dialect against Postgres' information_schema
+// views.
+type postgresDialect struct{}
+
+func (p *postgresDialect) ListTables(pool *sql.DB) ([]string, error) {
+	rows, err := pool.Query(`select table_name from information_schema.tables
+where table_schema = 'public' and table_type = 'BASE TABLE'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, fmt.Errorf("unable to scan table name: %v", err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func (p *postgresDialect) DescribeColumns(pool *sql.DB, table string) ([]*ColumnOutput, error) {
+	rows, err := pool.Query(`select c.column_name, c.data_type,
+       coalesce(tc.constraint_type, '') as key_type
+from information_schema.columns c
+left join information_schema.key_column_usage kcu
+       on kcu.table_schema = c.table_schema
+      and kcu.table_name = c.table_name
+      and kcu.column_name = c.column_name
+left join information_schema.table_constraints tc
+       on tc.constraint_name = kcu.constraint_name
+      and tc.table_schema = kcu.table_schema
+where c.table_schema = 'public' and c.table_name = $1
+order by c.ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []*ColumnOutput
+	byName := make(map[string]*ColumnOutput)
+	for rows.Next() {
+		columnOutput := &ColumnOutput{}
+		var constraintType string
+		if err := rows.Scan(&columnOutput.fieldName, &columnOutput.dataType,
+			&constraintType); err != nil {
+			return nil, fmt.Errorf("unable to scan table description result for table %s: %v",
+				table, err)
+		}
+		keyType := ""
+		switch constraintType {
+		case "PRIMARY KEY":
+			keyType = "PRI"
+		case "FOREIGN KEY":
+			keyType = "MUL"
+		}
+		// a column that takes part in several key constraints comes back once
+		// per constraint; keep a single entry, preferring PRI over MUL
+		if existing, ok := byName[columnOutput.fieldName]; ok {
+			if keyType == "PRI" || existing.keyType == "" {
+				existing.keyType = keyType
+			}
+			continue
+		}
+		columnOutput.keyType = keyType
+		byName[columnOutput.fieldName] = columnOutput
+		columns = append(columns, columnOutput)
+	}
+	return columns, nil
+}
+
+func (p *postgresDialect) ListForeignKeys(pool *sql.DB, table string) ([]*ForeignKeyRow, error) {
+	// constraint_column_usage carries no ordinal/position column, so joining
+	// to it directly pairs every local column with every referenced column
+	// of a multi-column constraint (a cross product) instead of the correct
+	// one-to-one pairing. Go through referential_constraints to find the
+	// referenced unique/primary key constraint, then join back into
+	// key_column_usage a second time and match position_in_unique_constraint
+	// against the referenced side's ordinal_position to pair columns
+	// correctly even when the constraint covers more than one column.
+	rows, err := pool.Query(`select kcu.column_name, tc.constraint_name,
+       ccu.table_name as referenced_table_name,
+       ccu.column_name as referenced_column_name
+from information_schema.table_constraints tc
+join information_schema.key_column_usage kcu
+  on kcu.constraint_name = tc.constraint_name
+ and kcu.table_schema = tc.table_schema
+join information_schema.referential_constraints rc
+  on rc.constraint_name = tc.constraint_name
+ and rc.constraint_schema = tc.table_schema
+join information_schema.key_column_usage ccu
+  on ccu.constraint_name = rc.unique_constraint_name
+ and ccu.constraint_schema = rc.unique_constraint_schema
+ and ccu.ordinal_position = kcu.position_in_unique_constraint
+where tc.constraint_type = 'FOREIGN KEY'
+  and tc.table_schema = 'public'
+  and tc.table_name = $1`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fkRows []*ForeignKeyRow
+	for rows.Next() {
+		fkRow := &ForeignKeyRow{}
+		err := rows.Scan(&fkRow.columnName, &fkRow.constraintName, &fkRow.referencedTableName,
+			&fkRow.referencedColumnName)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan usage info for table %s: %v", table, err)
+		}
+		fkRows = append(fkRows, fkRow)
+	}
+	return fkRows, nil
+}
+
+func (p *postgresDialect) MapType(dataType string) DataType {
+	for prefix, goType := range postgresTypePrefixToGoType {
+		if strings.HasPrefix(dataType, prefix) {
+			return goType
+		}
+	}
+	return UnknownType
+}
+
+func (p *postgresDialect) SampleClause(fraction float64) string {
+	return fmt.Sprintf("RANDOM() <= %f", fraction)
+}
+
+func (p *postgresDialect) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
@@ -0,0 +1,253 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// blankNodeID returns the blank-node identifier used to represent a row of
+// table, derived from the ordered values of its (possibly composite)
+// primary key, e.g. blankNodeID("registration", []string{"1", "2"}) returns
+// "_:registration_1_2".
+func blankNodeID(table string, pkValues []string) string {
+	return "_:" + table + "_" + strings.Join(pkValues, "_")
+}
+
+// generateRDF queries every row of every non-join table and writes out the
+// corresponding RDF N-Quads: one blank node per row, one triple per scalar
+// column, and one uid triple per foreign key constraint pointing at the
+// blank node of the referenced row. Join tables (see classifyTables) don't
+// get a node of their own; each of their rows instead becomes a pair of
+// reverse uid edges directly between the two tables they connect.
+func generateRDF(tables map[string]*TableInfo, pool *sql.DB, w io.Writer) error {
+	for _, tableName := range sortedTableNames(tables) {
+		tableInfo := tables[tableName]
+		if tableInfo.IsJoinTable {
+			if err := generateJoinTableRDF(tables, tableInfo, pool, w); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := generateTableRDF(tables, tableInfo, pool, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// columnOrder returns the table's columns in a stable order, primary key
+// columns first (in declaration order), followed by the rest sorted by name.
+func columnOrder(tableInfo *TableInfo) []string {
+	seen := make(map[string]bool)
+	order := make([]string, 0, len(tableInfo.columns))
+	for _, pkColumn := range tableInfo.primaryKeyColumns {
+		order = append(order, pkColumn)
+		seen[pkColumn] = true
+	}
+	rest := make([]string, 0, len(tableInfo.columns))
+	for columnName := range tableInfo.columns {
+		if !seen[columnName] {
+			rest = append(rest, columnName)
+		}
+	}
+	sort.Strings(rest)
+	order = append(order, rest...)
+	return order
+}
+
+func generateTableRDF(tables map[string]*TableInfo, tableInfo *TableInfo, pool *sql.DB, w io.Writer) error {
+	columns := columnOrder(tableInfo)
+	query := fmt.Sprintf("select %s from %s", strings.Join(columns, ", "), tableInfo.tableName)
+	rows, err := pool.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		values, err := scanRowValues(rows, len(columns))
+		if err != nil {
+			return err
+		}
+		rowByColumn := make(map[string]string, len(columns))
+		for i, columnName := range columns {
+			rowByColumn[columnName] = values[i]
+		}
+
+		if err := writeRowRDF(tables, tableInfo, columns, rowByColumn, w); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// remotePKValues resolves constraint's parts against rowByColumn (a row of
+// constraint's local table) into a tuple ordered to match
+// remoteTableInfo.primaryKeyColumns, the same ordering generateTableRDF used
+// to build that remote row's own subject blank node id. Nothing guarantees a
+// foreign key's columns are declared in the same order as the primary key
+// they reference, so this can't just use constraint.parts order directly.
+func remotePKValues(remoteTableInfo *TableInfo, constraint *ForeignKeyConstraint,
+	rowByColumn map[string]string) []string {
+	values := make([]string, len(remoteTableInfo.primaryKeyColumns))
+	for _, part := range constraint.parts {
+		values[indexOf(remoteTableInfo.primaryKeyColumns, part.remoteColumnName)] = rowByColumn[part.columnName]
+	}
+	return values
+}
+
+// writeRowRDF emits the RDF triples for a single already-fetched row of
+// tableInfo: a scalar triple per column that isn't part of a foreign key
+// (primary key columns included) and a uid triple per foreign key
+// constraint, pointing at the blank node of the referenced row.
+// columns fixes the order in which predicates are emitted.
+func writeRowRDF(tables map[string]*TableInfo, tableInfo *TableInfo, columns []string,
+	rowByColumn map[string]string, w io.Writer) error {
+	fkColumnToConstraint := make(map[string]*ForeignKeyConstraint)
+	for _, constraint := range tableInfo.foreignKeyConstraints {
+		for _, part := range constraint.parts {
+			fkColumnToConstraint[part.columnName] = constraint
+		}
+	}
+
+	pkValues := make([]string, len(tableInfo.primaryKeyColumns))
+	for i, pkColumn := range tableInfo.primaryKeyColumns {
+		pkValues[i] = rowByColumn[pkColumn]
+	}
+	subject := blankNodeID(tableInfo.tableName, pkValues)
+
+	emittedConstraints := make(map[*ForeignKeyConstraint]bool)
+	for _, columnName := range columns {
+		value := rowByColumn[columnName]
+		if constraint, ok := fkColumnToConstraint[columnName]; ok {
+			if emittedConstraints[constraint] {
+				continue
+			}
+			emittedConstraints[constraint] = true
+
+			remoteTable := constraint.parts[0].remoteTableName
+			object := blankNodeID(remoteTable, remotePKValues(tables[remoteTable], constraint, rowByColumn))
+			if _, err := fmt.Fprintf(w, "%s <%s> %s .\n", subject,
+				predicateName(tableInfo, constraint), object); err != nil {
+				return err
+			}
+			continue
+		}
+
+		predicate := fmt.Sprintf("%s.%s", tableInfo.tableName, columnName)
+		if _, err := fmt.Fprintf(w, "%s <%s> %q .\n", subject, predicate, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func generateJoinTableRDF(tables map[string]*TableInfo, tableInfo *TableInfo, pool *sql.DB, w io.Writer) error {
+	columns := columnOrder(tableInfo)
+	query := fmt.Sprintf("select %s from %s", strings.Join(columns, ", "), tableInfo.tableName)
+	rows, err := pool.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		values, err := scanRowValues(rows, len(columns))
+		if err != nil {
+			return err
+		}
+		rowByColumn := make(map[string]string, len(columns))
+		for i, columnName := range columns {
+			rowByColumn[columnName] = values[i]
+		}
+
+		if err := writeJoinRowRDF(tables, tableInfo, rowByColumn, w); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// writeJoinRowRDF emits the pair of reverse uid edges a single
+// already-fetched row of join table tableInfo stands in for, directly
+// between the two tables it relates.
+func writeJoinRowRDF(tables map[string]*TableInfo, tableInfo *TableInfo, rowByColumn map[string]string,
+	w io.Writer) error {
+	constraints := tableInfo.JoinEdges
+	leftTable := constraints[0].parts[0].remoteTableName
+	rightTable := constraints[1].parts[0].remoteTableName
+	leftPredicate := joinPredicate(leftTable, constraints[1])
+	rightPredicate := joinPredicate(rightTable, constraints[0])
+
+	leftNode := blankNodeID(leftTable, remotePKValues(tables[leftTable], constraints[0], rowByColumn))
+	rightNode := blankNodeID(rightTable, remotePKValues(tables[rightTable], constraints[1], rowByColumn))
+
+	if _, err := fmt.Fprintf(w, "%s <%s> %s .\n", leftNode, leftPredicate, rightNode); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s <%s> %s .\n", rightNode, rightPredicate, leftNode); err != nil {
+		return err
+	}
+	return nil
+}
+
+// scanRowValues scans the current row of rows into n string values,
+// converting every column to its string representation regardless of its
+// underlying SQL type.
+func scanRowValues(rows *sql.Rows, n int) ([]string, error) {
+	raw := make([]sql.NullString, n)
+	dest := make([]interface{}, n)
+	for i := range raw {
+		dest[i] = &raw[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, fmt.Errorf("unable to scan row: %v", err)
+	}
+
+	values := make([]string, n)
+	for i, v := range raw {
+		values[i] = v.String
+	}
+	return values, nil
+}
+
+// generateSubsetRDF writes the RDF triples for the rows a Subsetter fetched,
+// in the order it fetched them (parents before children), instead of
+// re-querying every table in full. A non-zero ChildDepth routinely walks
+// into join tables (e.g. student -> registration), so join-table rows need
+// the same bidirectional-edge treatment generateJoinTableRDF gives them
+// rather than being run through writeRowRDF's per-column predicate logic.
+func generateSubsetRDF(tables map[string]*TableInfo, subsetRows []*SubsetRow, w io.Writer) error {
+	for _, row := range subsetRows {
+		tableInfo := tables[row.Table]
+		if tableInfo.IsJoinTable {
+			if err := writeJoinRowRDF(tables, tableInfo, row.Columns, w); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeRowRDF(tables, tableInfo, columnOrder(tableInfo), row.Columns, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
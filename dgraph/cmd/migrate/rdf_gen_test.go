@@ -0,0 +1,120 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// composite-key fixtures: "enrollment" has a composite PK declared as
+// (year, term), while the referencing "grade" table's FK columns are
+// declared in the opposite order (term_id, year_id) -- exactly the
+// mismatch writeRowRDF's blank node id needs to resolve correctly.
+func compositeKeyFixture() (parent, child *TableInfo) {
+	parent = &TableInfo{
+		tableName:         "enrollment",
+		columns:           map[string]*ColumnInfo{"year": {}, "term": {}},
+		primaryKeyColumns: []string{"year", "term"},
+	}
+
+	constraint := &ForeignKeyConstraint{
+		parts: []*ConstraintPart{
+			{tableName: "grade", columnName: "term_id", remoteTableName: "enrollment", remoteColumnName: "term"},
+			{tableName: "grade", columnName: "year_id", remoteTableName: "enrollment", remoteColumnName: "year"},
+		},
+	}
+	child = &TableInfo{
+		tableName: "grade",
+		columns:   map[string]*ColumnInfo{"term_id": {}, "year_id": {}, "score": {}},
+		foreignKeyConstraints: map[string]*ForeignKeyConstraint{
+			"fk_grade_enrollment": constraint,
+		},
+	}
+	return parent, child
+}
+
+func TestWriteRowRDFCompositeForeignKeyMatchesParentBlankNode(t *testing.T) {
+	parent, child := compositeKeyFixture()
+	tables := map[string]*TableInfo{"enrollment": parent, "grade": child}
+
+	var parentBuf bytes.Buffer
+	parentRow := map[string]string{"year": "2024", "term": "fall"}
+	if err := writeRowRDF(tables, parent, columnOrder(parent), parentRow, &parentBuf); err != nil {
+		t.Fatalf("writeRowRDF(parent): %v", err)
+	}
+	parentSubject := strings.SplitN(parentBuf.String(), " ", 2)[0]
+
+	var childBuf bytes.Buffer
+	childRow := map[string]string{"term_id": "fall", "year_id": "2024", "score": "95"}
+	if err := writeRowRDF(tables, child, columnOrder(child), childRow, &childBuf); err != nil {
+		t.Fatalf("writeRowRDF(child): %v", err)
+	}
+
+	if !strings.Contains(childBuf.String(), parentSubject) {
+		t.Fatalf("child's FK edge does not reference the parent's blank node id %q; got:\n%s",
+			parentSubject, childBuf.String())
+	}
+}
+
+func TestGenerateSubsetRDFUsesJoinTableEdgesForJoinTableRows(t *testing.T) {
+	registration := joinTableFixture()
+	tables := map[string]*TableInfo{
+		"registration": registration,
+		"student":      {tableName: "student", primaryKeyColumns: []string{"id"}},
+		"course":       {tableName: "course", primaryKeyColumns: []string{"id"}},
+	}
+	registration.IsJoinTable, registration.JoinEdges = classifyTable(registration)
+	if !registration.IsJoinTable {
+		t.Fatal("fixture is expected to classify as a join table")
+	}
+
+	subsetRows := []*SubsetRow{{
+		Table:   "registration",
+		Columns: map[string]string{"student_id": "1", "course_id": "2", "created_at": "now"},
+	}}
+
+	var buf bytes.Buffer
+	if err := generateSubsetRDF(tables, subsetRows, &buf); err != nil {
+		t.Fatalf("generateSubsetRDF: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "registration.student_id") || strings.Contains(out, "registration.course_id") {
+		t.Fatalf("subset RDF emitted ad hoc predicates for a join table row that generateSchema never declares; got:\n%s", out)
+	}
+	if !strings.Contains(out, "student.courses") || !strings.Contains(out, "course.students") {
+		t.Fatalf("subset RDF did not emit the join table's declared bidirectional predicates; got:\n%s", out)
+	}
+}
+
+func TestPredicateNameDistinguishesMultipleForeignKeysToSameTable(t *testing.T) {
+	order := &TableInfo{tableName: "order"}
+	shipFK := &ForeignKeyConstraint{parts: []*ConstraintPart{
+		{tableName: "order", columnName: "ship_address_id", remoteTableName: "address", remoteColumnName: "id"},
+	}}
+	billFK := &ForeignKeyConstraint{parts: []*ConstraintPart{
+		{tableName: "order", columnName: "bill_address_id", remoteTableName: "address", remoteColumnName: "id"},
+	}}
+
+	shipPredicate := predicateName(order, shipFK)
+	billPredicate := predicateName(order, billFK)
+	if shipPredicate == billPredicate {
+		t.Fatalf("predicateName collapsed two distinct foreign keys onto one predicate: %q", shipPredicate)
+	}
+}
@@ -0,0 +1,220 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/dgraph-io/dgraph/x"
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// Migrate is the sub-command invoked as "dgraph migrate". It introspects a
+// relational database and turns it into a Dgraph schema.
+var Migrate x.SubCommand
+
+func init() {
+	Migrate.Cmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Run the Dgraph migration tool",
+		Run: func(cmd *cobra.Command, args []string) {
+			run()
+		},
+	}
+	Migrate.EnvPrefix = "DGRAPH_MIGRATE"
+
+	flag := Migrate.Cmd.Flags()
+	flag.String("driver", "mysql", "The database driver to migrate from, one of mysql or postgres")
+	flag.String("conn", "", "The connection string used to connect to the source database")
+	flag.String("output-schema", "schema.dgraph", "The file to write the generated Dgraph schema to")
+	flag.String("output-rdf", "out.rdf", "The file to write the generated RDF mutations to")
+	flag.String("subset", "", "Path to a YAML config describing a referentially-consistent "+
+		"subset of the source database to migrate, instead of the whole thing")
+
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare the source database schema against the last migrate snapshot",
+		Run: func(cmd *cobra.Command, args []string) {
+			runDiff(cmd)
+		},
+	}
+	diffFlag := diffCmd.Flags()
+	diffFlag.String("driver", "mysql", "The database driver to migrate from, one of mysql or postgres")
+	diffFlag.String("conn", "", "The connection string used to connect to the source database")
+	diffFlag.String("snapshot", "snapshot.json", "Path to the schema snapshot from the last run")
+	diffFlag.String("output-schema-patch", "schema.patch",
+		"The file to write the Dgraph schema alter patch to")
+	diffFlag.String("output-rdf-delta", "delta.rdf",
+		"The file to write the RDF delta for changed tables to")
+	Migrate.Cmd.AddCommand(diffCmd)
+}
+
+// introspect opens pool, lists its tables and fully introspects each one,
+// returning the same map[string]*TableInfo shape used throughout the rest
+// of the package.
+func introspect(dialect Dialect, pool *sql.DB) (map[string]*TableInfo, error) {
+	tables, err := dialect.ListTables(pool)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list tables: %v", err)
+	}
+
+	tableInfos := make(map[string]*TableInfo)
+	for _, table := range tables {
+		tableInfo, err := getTableInfo(table, pool, dialect)
+		if err != nil {
+			return nil, fmt.Errorf("unable to introspect table %s: %v", table, err)
+		}
+		tableInfos[table] = tableInfo
+	}
+
+	populateReferencedByColumns(tableInfos)
+	classifyTables(tableInfos)
+	return tableInfos, nil
+}
+
+func run() {
+	conf := Migrate.Conf
+	driver := conf.GetString("driver")
+	connString := conf.GetString("conn")
+
+	dialect, err := getDialect(driver)
+	if err != nil {
+		glog.Fatalf("unable to create dialect: %v", err)
+	}
+
+	pool, err := sql.Open(driver, connString)
+	if err != nil {
+		glog.Fatalf("unable to connect to the source database: %v", err)
+	}
+	defer pool.Close()
+
+	tableInfos, err := introspect(dialect, pool)
+	if err != nil {
+		glog.Fatalf("%v", err)
+	}
+
+	schemaFile, err := os.Create(conf.GetString("output-schema"))
+	if err != nil {
+		glog.Fatalf("unable to create schema output file: %v", err)
+	}
+	defer schemaFile.Close()
+	if err := generateSchema(tableInfos, schemaFile); err != nil {
+		glog.Fatalf("unable to generate schema: %v", err)
+	}
+
+	rdfFile, err := os.Create(conf.GetString("output-rdf"))
+	if err != nil {
+		glog.Fatalf("unable to create RDF output file: %v", err)
+	}
+	defer rdfFile.Close()
+
+	if subsetConfigPath := conf.GetString("subset"); len(subsetConfigPath) > 0 {
+		subsetConfig, err := loadSubsetConfig(subsetConfigPath)
+		if err != nil {
+			glog.Fatalf("unable to load subset config: %v", err)
+		}
+		subsetter := NewSubsetter(tableInfos, pool, dialect, subsetConfig)
+		subsetRows, err := subsetter.Run()
+		if err != nil {
+			glog.Fatalf("unable to compute subset: %v", err)
+		}
+		if err := generateSubsetRDF(tableInfos, subsetRows, rdfFile); err != nil {
+			glog.Fatalf("unable to generate RDF for subset: %v", err)
+		}
+		return
+	}
+
+	if err := generateRDF(tableInfos, pool, rdfFile); err != nil {
+		glog.Fatalf("unable to generate RDF: %v", err)
+	}
+}
+
+// runDiff introspects the source database, compares it against the
+// snapshot left by the previous run, writes out a schema alter patch and an
+// RDF delta covering only the tables that changed, and then updates the
+// snapshot so the next run diffs against what was just observed.
+func runDiff(cmd *cobra.Command) {
+	flag := cmd.Flags()
+	driver, _ := flag.GetString("driver")
+	connString, _ := flag.GetString("conn")
+	snapshotPath, _ := flag.GetString("snapshot")
+
+	dialect, err := getDialect(driver)
+	if err != nil {
+		glog.Fatalf("unable to create dialect: %v", err)
+	}
+
+	pool, err := sql.Open(driver, connString)
+	if err != nil {
+		glog.Fatalf("unable to connect to the source database: %v", err)
+	}
+	defer pool.Close()
+
+	tableInfos, err := introspect(dialect, pool)
+	if err != nil {
+		glog.Fatalf("%v", err)
+	}
+	current := snapshotTables(tableInfos)
+
+	previous, err := loadSnapshot(snapshotPath)
+	if err != nil {
+		glog.Fatalf("unable to load snapshot %s: %v", snapshotPath, err)
+	}
+
+	diff := diffSnapshots(previous, current)
+
+	patchPath, _ := flag.GetString("output-schema-patch")
+	patchFile, err := os.Create(patchPath)
+	if err != nil {
+		glog.Fatalf("unable to create schema patch file: %v", err)
+	}
+	defer patchFile.Close()
+	if err := generateDiffSchema(diff, tableInfos, patchFile); err != nil {
+		glog.Fatalf("unable to generate schema patch: %v", err)
+	}
+
+	deltaPath, _ := flag.GetString("output-rdf-delta")
+	deltaFile, err := os.Create(deltaPath)
+	if err != nil {
+		glog.Fatalf("unable to create RDF delta file: %v", err)
+	}
+	defer deltaFile.Close()
+	if err := generateDiffRDF(diff, tableInfos, pool, deltaFile); err != nil {
+		glog.Fatalf("unable to generate RDF delta: %v", err)
+	}
+
+	if err := writeSnapshot(snapshotPath, current); err != nil {
+		glog.Fatalf("unable to write snapshot %s: %v", snapshotPath, err)
+	}
+}
+
+func loadSubsetConfig(path string) (*SubsetConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &SubsetConfig{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
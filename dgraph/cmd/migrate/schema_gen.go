@@ -0,0 +1,165 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// dgraphScalarType maps a migrate DataType to the Dgraph scalar type used
+// when declaring a predicate in the generated schema.
+func dgraphScalarType(dataType DataType) string {
+	switch dataType {
+	case IntType, Int64Type:
+		return "int"
+	case FloatType:
+		return "float"
+	case BoolType:
+		return "bool"
+	case DateTimeType:
+		return "datetime"
+	default:
+		return "string"
+	}
+}
+
+// predicateName returns the name of the Dgraph predicate used to represent
+// the edge from constraint's table to the table it references. It's keyed
+// off the local foreign key column(s) rather than just the two table names,
+// since a table can have more than one foreign key pointing at the same
+// remote table (e.g. order.ship_address_id and order.bill_address_id both
+// referencing address), which would otherwise collapse onto one predicate.
+func predicateName(tableInfo *TableInfo, constraint *ForeignKeyConstraint) string {
+	columnNames := make([]string, len(constraint.parts))
+	for i, part := range constraint.parts {
+		columnNames[i] = part.columnName
+	}
+	return fmt.Sprintf("%s.%s", tableInfo.tableName, strings.Join(columnNames, "_"))
+}
+
+// generateSchema writes a Dgraph schema describing tables, declaring a
+// scalar predicate per column that isn't part of a foreign key (indexed for
+// primary key columns) and a uid predicate (with @reverse) per foreign key
+// constraint so that the reverse direction can be queried
+// without a second predicate. Join tables (see classifyTables) don't get a
+// type of their own; instead each gets a single [uid] @reverse predicate
+// declared on the two tables it relates.
+func generateSchema(tables map[string]*TableInfo, w io.Writer) error {
+	for _, tableName := range sortedTableNames(tables) {
+		tableInfo := tables[tableName]
+		if tableInfo.IsJoinTable {
+			if err := generateJoinTableSchema(tableInfo, w); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := generateTableSchema(tableInfo, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generateTableSchema writes the scalar and uid predicate declarations for
+// a single non-join table.
+func generateTableSchema(tableInfo *TableInfo, w io.Writer) error {
+	// foreign key columns are represented by the constraint's uid predicate
+	// below, not by a scalar predicate of their own
+	fkColumns := make(map[string]bool)
+	for _, constraint := range tableInfo.foreignKeyConstraints {
+		for _, part := range constraint.parts {
+			fkColumns[part.columnName] = true
+		}
+	}
+	columnNames := make([]string, 0, len(tableInfo.columns))
+	for columnName := range tableInfo.columns {
+		if !fkColumns[columnName] {
+			columnNames = append(columnNames, columnName)
+		}
+	}
+	sort.Strings(columnNames)
+
+	for _, columnName := range columnNames {
+		columnInfo := tableInfo.columns[columnName]
+		predicate := fmt.Sprintf("%s.%s", tableInfo.tableName, columnName)
+		indexSuffix := " ."
+		if columnInfo.keyType == PRIMARY {
+			indexSuffix = " @index(hash) ."
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s%s\n", predicate, dgraphScalarType(columnInfo.dataType),
+			indexSuffix); err != nil {
+			return err
+		}
+	}
+
+	constraintNames := make([]string, 0, len(tableInfo.foreignKeyConstraints))
+	for constraintName := range tableInfo.foreignKeyConstraints {
+		constraintNames = append(constraintNames, constraintName)
+	}
+	sort.Strings(constraintNames)
+	for _, constraintName := range constraintNames {
+		constraint := tableInfo.foreignKeyConstraints[constraintName]
+		if _, err := fmt.Fprintf(w, "%s: uid @reverse .\n",
+			predicateName(tableInfo, constraint)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinPredicate returns the name of the predicate a join table declares on
+// localTable, for the direction identified by otherEdge (the join table's
+// other foreign key). It's keyed off otherEdge's own local column name
+// rather than just its remote table name: for a join table relating two
+// different tables the two happen to coincide (a "registration" table's
+// student_id/course_id edges name student.courses / course.students either
+// way), but for a join table relating a table to itself (e.g. a
+// "friendship" table with user_id/friend_id both pointing at "user") naming
+// by remote table alone would collapse both directions onto the same
+// "user.users" predicate.
+func joinPredicate(localTable string, otherEdge *ForeignKeyConstraint) string {
+	return fmt.Sprintf("%s.%ss", localTable, strings.TrimSuffix(otherEdge.parts[0].columnName, "_id"))
+}
+
+// generateJoinTableSchema declares the bidirectional [uid] @reverse
+// predicate standing in for a join table, e.g. a "registration" table
+// relating "student" and "course" becomes student.courses / course.students.
+func generateJoinTableSchema(tableInfo *TableInfo, w io.Writer) error {
+	leftEdge, rightEdge := tableInfo.JoinEdges[0], tableInfo.JoinEdges[1]
+	leftTable := leftEdge.parts[0].remoteTableName
+	rightTable := rightEdge.parts[0].remoteTableName
+
+	if _, err := fmt.Fprintf(w, "%s: [uid] @reverse .\n", joinPredicate(leftTable, rightEdge)); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s: [uid] @reverse .\n", joinPredicate(rightTable, leftEdge)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func sortedTableNames(tables map[string]*TableInfo) []string {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
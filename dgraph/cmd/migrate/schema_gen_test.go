@@ -0,0 +1,118 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTableSchemaDeclaresScalarsAndForeignKeys(t *testing.T) {
+	tableInfo := &TableInfo{
+		tableName: "student",
+		columns: map[string]*ColumnInfo{
+			"id":      {keyType: PRIMARY, dataType: IntType},
+			"name":    {dataType: StringType},
+			"advisor": {dataType: IntType},
+		},
+		foreignKeyConstraints: map[string]*ForeignKeyConstraint{
+			"fk_student_advisor": {parts: []*ConstraintPart{
+				{tableName: "student", columnName: "advisor", remoteTableName: "teacher", remoteColumnName: "id"},
+			}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := generateTableSchema(tableInfo, &buf); err != nil {
+		t.Fatalf("generateTableSchema: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "student.id: int @index(hash) .") {
+		t.Fatalf("missing indexed primary key predicate; got:\n%s", out)
+	}
+	if !strings.Contains(out, "student.name: string .") {
+		t.Fatalf("missing scalar predicate; got:\n%s", out)
+	}
+	if !strings.Contains(out, "student.advisor: uid @reverse .") {
+		t.Fatalf("missing foreign key predicate; got:\n%s", out)
+	}
+}
+
+func TestGenerateJoinTableSchemaDeclaresBidirectionalPredicates(t *testing.T) {
+	tableInfo := joinTableFixture()
+	tableInfo.IsJoinTable, tableInfo.JoinEdges = classifyTable(tableInfo)
+
+	var buf bytes.Buffer
+	if err := generateJoinTableSchema(tableInfo, &buf); err != nil {
+		t.Fatalf("generateJoinTableSchema: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "student.courses: [uid] @reverse .") {
+		t.Fatalf("missing student.courses predicate; got:\n%s", out)
+	}
+	if !strings.Contains(out, "course.students: [uid] @reverse .") {
+		t.Fatalf("missing course.students predicate; got:\n%s", out)
+	}
+}
+
+// friendshipFixture is a join table relating "user" to itself, e.g. a
+// friendship table with distinct foreign keys user_id and friend_id both
+// pointing at "user". Naming a join table's predicates purely off the
+// remote table name would declare "user.users" for both directions.
+func friendshipFixture() *TableInfo {
+	return &TableInfo{
+		tableName:         "friendship",
+		primaryKeyColumns: []string{"user_id", "friend_id"},
+		columns: map[string]*ColumnInfo{
+			"user_id":   {keyType: PRIMARY},
+			"friend_id": {keyType: PRIMARY},
+		},
+		foreignKeyConstraints: map[string]*ForeignKeyConstraint{
+			"fk_friendship_user": {parts: []*ConstraintPart{
+				{tableName: "friendship", columnName: "user_id", remoteTableName: "user", remoteColumnName: "id"},
+			}},
+			"fk_friendship_friend": {parts: []*ConstraintPart{
+				{tableName: "friendship", columnName: "friend_id", remoteTableName: "user", remoteColumnName: "id"},
+			}},
+		},
+	}
+}
+
+func TestGenerateJoinTableSchemaDisambiguatesSelfReferencingJoinTable(t *testing.T) {
+	tableInfo := friendshipFixture()
+	isJoin, edges := classifyTable(tableInfo)
+	if !isJoin {
+		t.Fatal("fixture is expected to classify as a join table")
+	}
+	tableInfo.IsJoinTable, tableInfo.JoinEdges = isJoin, edges
+
+	var buf bytes.Buffer
+	if err := generateJoinTableSchema(tableInfo, &buf); err != nil {
+		t.Fatalf("generateJoinTableSchema: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected exactly 2 predicate declarations, got %d:\n%s", len(lines), buf.String())
+	}
+	if lines[0] == lines[1] {
+		t.Fatalf("self-referencing join table collapsed both directions onto the same predicate: %q", lines[0])
+	}
+}
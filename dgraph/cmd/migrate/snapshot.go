@@ -0,0 +1,112 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+)
+
+// columnSnapshot is the serializable form of a ColumnInfo.
+type columnSnapshot struct {
+	Name     string   `json:"name"`
+	DataType DataType `json:"dataType"`
+	KeyType  KeyType  `json:"keyType"`
+}
+
+// foreignKeySnapshot is the serializable form of a ForeignKeyConstraint.
+type foreignKeySnapshot struct {
+	ConstraintName string   `json:"constraintName"`
+	Columns        []string `json:"columns"`
+	RemoteTable    string   `json:"remoteTable"`
+	RemoteColumns  []string `json:"remoteColumns"`
+}
+
+// tableSnapshot is the serializable form of a TableInfo, used to persist
+// the last-observed schema to disk between "migrate diff" runs.
+type tableSnapshot struct {
+	Columns           []columnSnapshot     `json:"columns"`
+	PrimaryKeyColumns []string             `json:"primaryKeyColumns"`
+	ForeignKeys       []foreignKeySnapshot `json:"foreignKeys"`
+}
+
+// schemaSnapshot is the serializable form of the map[string]*TableInfo
+// produced by a "dgraph migrate" introspection run.
+type schemaSnapshot struct {
+	Tables map[string]*tableSnapshot `json:"tables"`
+}
+
+// snapshotTables converts the live, introspected tables into the
+// serializable form written to and read from the snapshot file.
+func snapshotTables(tables map[string]*TableInfo) *schemaSnapshot {
+	snapshot := &schemaSnapshot{Tables: make(map[string]*tableSnapshot, len(tables))}
+	for tableName, tableInfo := range tables {
+		ts := &tableSnapshot{
+			PrimaryKeyColumns: tableInfo.primaryKeyColumns,
+		}
+		for _, columnName := range columnOrder(tableInfo) {
+			columnInfo := tableInfo.columns[columnName]
+			ts.Columns = append(ts.Columns, columnSnapshot{
+				Name:     columnName,
+				DataType: columnInfo.dataType,
+				KeyType:  columnInfo.keyType,
+			})
+		}
+		for constraintName, constraint := range tableInfo.foreignKeyConstraints {
+			fk := foreignKeySnapshot{
+				ConstraintName: constraintName,
+				RemoteTable:    constraint.parts[0].remoteTableName,
+			}
+			for _, part := range constraint.parts {
+				fk.Columns = append(fk.Columns, part.columnName)
+				fk.RemoteColumns = append(fk.RemoteColumns, part.remoteColumnName)
+			}
+			ts.ForeignKeys = append(ts.ForeignKeys, fk)
+		}
+		snapshot.Tables[tableName] = ts
+	}
+	return snapshot
+}
+
+// loadSnapshot reads a schemaSnapshot previously written by writeSnapshot.
+// A missing file is not an error: it simply means there's no prior
+// snapshot to diff against yet, so the caller gets a nil snapshot back.
+func loadSnapshot(path string) (*schemaSnapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	snapshot := &schemaSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// writeSnapshot persists snapshot to path, overwriting any prior snapshot,
+// so the next "migrate diff" run can compare against it.
+func writeSnapshot(path string, snapshot *schemaSnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,263 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// SubsetTableSpec describes how rows should be picked out of a single seed
+// table: either a random Fraction of its rows, or exactly the rows matching
+// the Where predicate. Setting both is an error; setting neither selects the
+// whole table.
+type SubsetTableSpec struct {
+	Fraction float64 `yaml:"fraction"`
+	Where    string  `yaml:"where"`
+}
+
+// SubsetConfig is the user-provided configuration for Subsetter, typically
+// loaded from the YAML file passed to "dgraph migrate --subset".
+type SubsetConfig struct {
+	// Tables maps a seed table name to how rows should be picked from it.
+	Tables map[string]*SubsetTableSpec `yaml:"tables"`
+
+	// ChildDepth bounds how many levels of constraintSources (dependent
+	// child rows) are followed below a fetched row. Zero means seed rows
+	// and their ancestors are fetched, but no descendants.
+	ChildDepth int `yaml:"childDepth"`
+}
+
+// SubsetRow is a single row pulled by a Subsetter, tagged with the table it
+// came from and its primary key values so the caller can generate a blank
+// node id for it.
+type SubsetRow struct {
+	Table    string
+	PKValues []string
+	Columns  map[string]string
+}
+
+// Subsetter streams a referentially-consistent subset of a relational
+// database: every row it fetches from a seed table pulls in, transitively,
+// every parent row its foreign keys point to, so the resulting rows never
+// dangle. It can optionally also follow constraintSources to pull in some
+// dependent child rows.
+type Subsetter struct {
+	tables  map[string]*TableInfo
+	pool    *sql.DB
+	dialect Dialect
+	config  *SubsetConfig
+
+	// fetched tracks which (table, pk-tuple) pairs have already been
+	// fetched, so cycles and diamond-shaped FK graphs don't cause duplicate
+	// work or infinite recursion.
+	fetched map[string]bool
+
+	// ordered accumulates fetched rows in topological order: a row is only
+	// appended after all of the parent rows it depends on have been.
+	ordered []*SubsetRow
+}
+
+// NewSubsetter creates a Subsetter over the already-introspected tables.
+func NewSubsetter(tables map[string]*TableInfo, pool *sql.DB, dialect Dialect,
+	config *SubsetConfig) *Subsetter {
+	return &Subsetter{
+		tables:  tables,
+		pool:    pool,
+		dialect: dialect,
+		config:  config,
+		fetched: make(map[string]bool),
+	}
+}
+
+// Run executes the subset and returns the fetched rows in topological order
+// (parents before children).
+func (s *Subsetter) Run() ([]*SubsetRow, error) {
+	for table, spec := range s.config.Tables {
+		tableInfo, ok := s.tables[table]
+		if !ok {
+			return nil, fmt.Errorf("subset config references unknown table %q", table)
+		}
+
+		seedRows, err := s.selectRows(tableInfo, spec)
+		if err != nil {
+			return nil, fmt.Errorf("unable to select seed rows for table %s: %v", table, err)
+		}
+		for _, row := range seedRows {
+			if err := s.fetch(tableInfo, row, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s.ordered, nil
+}
+
+// fetch registers row (from tableInfo) and all of its ancestors into
+// s.ordered, then, up to s.config.ChildDepth levels, does the same for its
+// dependent child rows.
+func (s *Subsetter) fetch(tableInfo *TableInfo, row map[string]string, depth int) error {
+	pkValues := make([]string, len(tableInfo.primaryKeyColumns))
+	for i, pkColumn := range tableInfo.primaryKeyColumns {
+		pkValues[i] = row[pkColumn]
+	}
+	key := fetchKey(tableInfo.tableName, pkValues)
+	if s.fetched[key] {
+		return nil
+	}
+	s.fetched[key] = true
+
+	for _, constraint := range tableInfo.foreignKeyConstraints {
+		remoteTable := constraint.parts[0].remoteTableName
+		remoteTableInfo, ok := s.tables[remoteTable]
+		if !ok {
+			continue
+		}
+		remotePK := remotePKValues(remoteTableInfo, constraint, row)
+		parentRow, err := s.selectRowByPK(remoteTableInfo, remotePK)
+		if err != nil {
+			return fmt.Errorf("unable to fetch parent row %s%v of %s: %v",
+				remoteTable, remotePK, tableInfo.tableName, err)
+		}
+		if parentRow == nil {
+			continue
+		}
+		if err := s.fetch(remoteTableInfo, parentRow, depth); err != nil {
+			return err
+		}
+	}
+
+	s.ordered = append(s.ordered, &SubsetRow{
+		Table:    tableInfo.tableName,
+		PKValues: pkValues,
+		Columns:  row,
+	})
+
+	if depth >= s.config.ChildDepth {
+		return nil
+	}
+	for _, constraint := range tableInfo.constraintSources {
+		childTable := constraint.parts[0].remoteTableName
+		childTableInfo, ok := s.tables[childTable]
+		if !ok {
+			continue
+		}
+		childRows, err := s.selectChildRows(childTableInfo, constraint, pkValues, tableInfo)
+		if err != nil {
+			return fmt.Errorf("unable to fetch child rows of %s in %s: %v",
+				tableInfo.tableName, childTable, err)
+		}
+		for _, childRow := range childRows {
+			if err := s.fetch(childTableInfo, childRow, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func fetchKey(table string, pkValues []string) string {
+	return table + "|" + strings.Join(pkValues, "|")
+}
+
+// selectRows runs the seed query for spec against tableInfo.
+func (s *Subsetter) selectRows(tableInfo *TableInfo, spec *SubsetTableSpec) ([]map[string]string, error) {
+	if len(spec.Where) > 0 && spec.Fraction > 0 {
+		return nil, fmt.Errorf("table %s: fraction and where are mutually exclusive",
+			tableInfo.tableName)
+	}
+
+	where := spec.Where
+	if len(where) == 0 && spec.Fraction > 0 {
+		where = s.dialect.SampleClause(spec.Fraction)
+	}
+	return s.selectRowsWhere(tableInfo, where)
+}
+
+// selectRowByPK fetches the single row of tableInfo identified by
+// pkValues (in tableInfo.primaryKeyColumns order), or nil if it doesn't
+// exist.
+func (s *Subsetter) selectRowByPK(tableInfo *TableInfo, pkValues []string) (map[string]string, error) {
+	conditions := make([]string, len(tableInfo.primaryKeyColumns))
+	args := make([]interface{}, len(pkValues))
+	for i, pkColumn := range tableInfo.primaryKeyColumns {
+		conditions[i] = fmt.Sprintf("%s = %s", pkColumn, s.dialect.Placeholder(i+1))
+		args[i] = pkValues[i]
+	}
+	rows, err := s.selectRowsWhere(tableInfo, strings.Join(conditions, " AND "), args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0], nil
+}
+
+// selectChildRows fetches the rows of childTableInfo whose foreign key
+// constraint points at parentPKValues in parentTableInfo.
+func (s *Subsetter) selectChildRows(childTableInfo *TableInfo, constraint *ForeignKeyConstraint,
+	parentPKValues []string, parentTableInfo *TableInfo) ([]map[string]string, error) {
+	conditions := make([]string, len(constraint.parts))
+	args := make([]interface{}, len(constraint.parts))
+	for i, part := range constraint.parts {
+		// part is from the parent's perspective (constraintSources stores
+		// the reverse constraint), so part.columnName is the parent's PK
+		// column and part.remoteColumnName is the child's FK column that
+		// references it.
+		conditions[i] = fmt.Sprintf("%s = %s", part.remoteColumnName, s.dialect.Placeholder(i+1))
+		args[i] = parentPKValues[indexOf(parentTableInfo.primaryKeyColumns, part.columnName)]
+	}
+	return s.selectRowsWhere(childTableInfo, strings.Join(conditions, " AND "), args...)
+}
+
+func indexOf(values []string, target string) int {
+	for i, v := range values {
+		if v == target {
+			return i
+		}
+	}
+	return 0
+}
+
+func (s *Subsetter) selectRowsWhere(tableInfo *TableInfo, where string, args ...interface{}) ([]map[string]string, error) {
+	columns := columnOrder(tableInfo)
+	query := fmt.Sprintf("select %s from %s", strings.Join(columns, ", "), tableInfo.tableName)
+	if len(where) > 0 {
+		query += " where " + where
+	}
+
+	rows, err := s.pool.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []map[string]string
+	for rows.Next() {
+		values, err := scanRowValues(rows, len(columns))
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(columns))
+		for i, columnName := range columns {
+			row[columnName] = values[i]
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
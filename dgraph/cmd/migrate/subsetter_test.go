@@ -0,0 +1,41 @@
+/*
+ * Copyright 2017-2019 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import "testing"
+
+func TestSelectRowsRejectsFractionAndWhereTogether(t *testing.T) {
+	s := &Subsetter{}
+	tableInfo := &TableInfo{tableName: "student"}
+	spec := &SubsetTableSpec{Fraction: 0.1, Where: "id > 10"}
+
+	if _, err := s.selectRows(tableInfo, spec); err == nil {
+		t.Fatal("expected an error when both Fraction and Where are set, got nil")
+	}
+}
+
+func TestRemotePKValuesOrdersByRemoteTablePrimaryKey(t *testing.T) {
+	parent, child := compositeKeyFixture()
+	constraint := child.foreignKeyConstraints["fk_grade_enrollment"]
+	childRow := map[string]string{"term_id": "fall", "year_id": "2024", "score": "95"}
+
+	got := remotePKValues(parent, constraint, childRow)
+	want := []string{"2024", "fall"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("remotePKValues = %v, want %v", got, want)
+	}
+}
@@ -18,8 +18,6 @@ package migrate
 
 import (
 	"database/sql"
-	"fmt"
-	"strings"
 
 	"github.com/dgraph-io/dgraph/x"
 )
@@ -34,6 +32,16 @@ const (
 
 type DataType int
 
+const (
+	UnknownType DataType = iota
+	IntType
+	Int64Type
+	FloatType
+	BoolType
+	StringType
+	DateTimeType
+)
+
 type ColumnInfo struct {
 	name     string
 	keyType  KeyType
@@ -59,6 +67,10 @@ type TableInfo struct {
 	tableName string
 	columns   map[string]*ColumnInfo
 
+	// the names of the primary key columns, in declaration order, so that
+	// composite keys can be turned into a stable, ordered tuple
+	primaryKeyColumns []string
+
 	// the referenced tables by the current table through foreign keys
 	referencedTables map[string]interface{}
 
@@ -67,6 +79,15 @@ type TableInfo struct {
 
 	// the list of foreign key constraints using this table as the target
 	constraintSources []*ForeignKeyConstraint
+
+	// IsJoinTable is set by classifyTables when this table looks like a
+	// many-to-many association table: exactly two foreign key constraints
+	// covering its whole primary key and no other meaningful data.
+	IsJoinTable bool
+
+	// JoinEdges holds the two foreign key constraints a join table relates,
+	// only set when IsJoinTable is true.
+	JoinEdges [2]*ForeignKeyConstraint
 }
 
 type ColumnOutput struct {
@@ -75,7 +96,7 @@ type ColumnOutput struct {
 	keyType   string
 }
 
-func getColumnInfo(columnOutput *ColumnOutput) *ColumnInfo {
+func getColumnInfo(columnOutput *ColumnOutput, dialect Dialect) *ColumnInfo {
 	columnInfo := ColumnInfo{}
 	columnInfo.name = columnOutput.fieldName
 	switch columnOutput.keyType {
@@ -85,24 +106,15 @@ func getColumnInfo(columnOutput *ColumnOutput) *ColumnInfo {
 		columnInfo.keyType = MULTI
 	}
 
-	for prefix, goType := range mysqlTypePrefixToGoType {
-		if strings.HasPrefix(columnOutput.dataType, prefix) {
-			columnInfo.dataType = goType
-			break
-		}
-	}
+	columnInfo.dataType = dialect.MapType(columnOutput.dataType)
 	return &columnInfo
 }
 
-func getTableInfo(table string, pool *sql.DB) (*TableInfo, error) {
-	query := fmt.Sprintf(`select COLUMN_NAME,DATA_TYPE,
-COLUMN_KEY from INFORMATION_SCHEMA.COLUMNS where TABLE_NAME = "%s"`, table)
-	rows, err := pool.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
+// getTableInfo introspects table through dialect and returns the resulting
+// TableInfo. The query shapes differ across databases (MySQL's
+// INFORMATION_SCHEMA vs Postgres' information_schema), which is
+// exactly what dialect abstracts away.
+func getTableInfo(table string, pool *sql.DB, dialect Dialect) (*TableInfo, error) {
 	tableInfo := &TableInfo{
 		tableName:             table,
 		columns:               make(map[string]*ColumnInfo),
@@ -110,65 +122,37 @@ COLUMN_KEY from INFORMATION_SCHEMA.COLUMNS where TABLE_NAME = "%s"`, table)
 		foreignKeyConstraints: make(map[string]*ForeignKeyConstraint),
 	}
 
-	for rows.Next() {
-		/*
-			each row represents info about a column, for example
-			+----------+-------------+------+-----+---------+-------+
-			| Field    | Type        | Null | Key | Default | Extra |
-			+----------+-------------+------+-----+---------+-------+
-			| ssn      | varchar(50) | NO   | PRI | NULL    |       |
-		*/
-
-		columnOutput := ColumnOutput{}
-		err := rows.Scan(&columnOutput.fieldName, &columnOutput.dataType, &columnOutput.keyType)
-		if err != nil {
-			return nil, fmt.Errorf("unable to scan table description result for table %s: %v",
-				table, err)
+	columns, err := dialect.DescribeColumns(pool, table)
+	if err != nil {
+		return nil, err
+	}
+	for _, columnOutput := range columns {
+		columnInfo := getColumnInfo(columnOutput, dialect)
+		tableInfo.columns[columnOutput.fieldName] = columnInfo
+		if columnInfo.keyType == PRIMARY {
+			tableInfo.primaryKeyColumns = append(tableInfo.primaryKeyColumns, columnOutput.fieldName)
 		}
-
-		tableInfo.columns[columnOutput.fieldName] = getColumnInfo(&columnOutput)
 	}
 
-	foreignKeysQuery := fmt.Sprintf(`select COLUMN_NAME, CONSTRAINT_NAME, REFERENCED_TABLE_NAME,
-		REFERENCED_COLUMN_NAME from INFORMATION_SCHEMA.KEY_COLUMN_USAGE where TABLE_NAME = "%s"
-        AND REFERENCED_TABLE_NAME IS NOT NULL`,
-		table)
-	foreignKeyRows, err := pool.Query(foreignKeysQuery)
+	fkRows, err := dialect.ListForeignKeys(pool, table)
 	if err != nil {
 		return nil, err
 	}
-	defer foreignKeyRows.Close()
-	for foreignKeyRows.Next() {
-		/* example output from MySQL when querying the registration table
-		+-------------+-----------------------+------------------------+
-		| COLUMN_NAME | REFERENCED_TABLE_NAME | REFERENCED_COLUMN_NAME |
-		+-------------+-----------------------+------------------------+
-		| student_id  | student               | id                     |
-		| course_id   | course                | id                     |
-		| faculty_id  | faculty               | id                     |
-		+-------------+-----------------------+------------------------+
-
-		*/
-		var columnName, constraintName, referencedTableName, referencedColumnName string
-		err := foreignKeyRows.Scan(&columnName, &constraintName, &referencedTableName,
-			&referencedColumnName)
-		if err != nil {
-			return nil, fmt.Errorf("unable to scan usage info for table %s: %v", table, err)
-		}
-
-		tableInfo.referencedTables[referencedTableName] = struct{}{}
+	for _, fkRow := range fkRows {
+		tableInfo.referencedTables[fkRow.referencedTableName] = struct{}{}
 		var constraint *ForeignKeyConstraint
-		if constraint, ok := tableInfo.foreignKeyConstraints[constraintName]; !ok {
+		constraint, ok := tableInfo.foreignKeyConstraints[fkRow.constraintName]
+		if !ok {
 			constraint = &ForeignKeyConstraint{
 				parts: make([]*ConstraintPart, 0),
 			}
-			tableInfo.foreignKeyConstraints[constraintName] = constraint
+			tableInfo.foreignKeyConstraints[fkRow.constraintName] = constraint
 		}
 		constraint.parts = append(constraint.parts, &ConstraintPart{
 			tableName:        table,
-			columnName:       columnName,
-			remoteTableName:  referencedTableName,
-			remoteColumnName: referencedColumnName,
+			columnName:       fkRow.columnName,
+			remoteTableName:  fkRow.referencedTableName,
+			remoteColumnName: fkRow.referencedColumnName,
 		})
 	}
 	return tableInfo, nil
@@ -185,7 +169,7 @@ func validateAndGetReverse(constraint *ForeignKeyConstraint) (string, *ForeignKe
 			x.AssertTrue(part.remoteTableName == remoteTableName)
 		}
 		reverseParts = append(reverseParts, &ConstraintPart{
-			tableName:        part.remoteColumnName,
+			tableName:        part.remoteTableName,
 			columnName:       part.remoteColumnName,
 			remoteTableName:  part.tableName,
 			remoteColumnName: part.columnName,